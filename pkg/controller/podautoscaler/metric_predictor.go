@@ -0,0 +1,151 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podautoscaler
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"k8s.io/kubernetes/pkg/apis/extensions"
+)
+
+const (
+	// PredictiveMetricsAnnotationName holds a JSON list of the metric
+	// names (matching the `Name` of an entry in `spec.metrics`) that an
+	// HPA in predictive mode should forecast. Metrics not named here are
+	// scaled the normal, non-predictive way even when isPredictive is
+	// true, so operators opt individual metrics in deliberately.
+	PredictiveMetricsAnnotationName = "predictive.metrics"
+)
+
+// MetricTarget is the subset of an HPA's `spec.metrics` entry that the
+// predictive path needs: which metric to forecast and the utilization
+// it's being scaled to.
+type MetricTarget struct {
+	// Name identifies the metric, e.g. "cpu", "memory", or a custom or
+	// external metric name such as "queue_depth".
+	Name string
+	// TargetUtilization is the per-pod value this metric is being
+	// scaled to hold.
+	TargetUtilization float64
+}
+
+// MetricPredictionResult is the outcome of forecasting a single metric.
+type MetricPredictionResult struct {
+	MetricName      string
+	PredictedValue  float64
+	DesiredReplicas int32
+}
+
+// predictiveMetricNames returns the metric names this HPA has opted
+// into predictive scaling for, parsed from the JSON array stored in the
+// PredictiveMetricsAnnotationName annotation.
+func predictiveMetricNames(hpa *extensions.HorizontalPodAutoscaler) ([]string, error) {
+	raw, found := hpa.Annotations[PredictiveMetricsAnnotationName]
+	if !found {
+		return nil, nil
+	}
+
+	var metricNames []string
+	if err := json.Unmarshal([]byte(raw), &metricNames); err != nil {
+		return nil, fmt.Errorf("could not parse %s annotation as a JSON list of metric names: %v", PredictiveMetricsAnnotationName, err)
+	}
+
+	return metricNames, nil
+}
+
+// metricObservationKey namespaces an ObservationStore key by both the
+// HPA and the metric name, so a single ObservationStore can hold
+// parallel histories for every metric an HPA predicts on.
+func metricObservationKey(hpaUID string, metricName string) string {
+	return hpaUID + "/" + metricName
+}
+
+// desiredReplicasForMetric computes the desired replica count implied by
+// a single metric's predicted value relative to its target utilization,
+// the same ratio the non-predictive HPA path uses to scale on one
+// metric. The result is floored at 0: a negative ratio (e.g. a sharply
+// declining forecast) carries no more actionable information than 0
+// once it reaches a caller expecting a replica count, and nothing
+// downstream guards against trying to scale to a negative number of
+// pods.
+func desiredReplicasForMetric(currentReplicas int32, predictedValue float64, targetUtilization float64) int32 {
+	if targetUtilization <= 0 {
+		return currentReplicas
+	}
+
+	usageRatio := predictedValue / targetUtilization
+	desired := int32(math.Ceil(float64(currentReplicas) * usageRatio))
+	if desired < 0 {
+		return 0
+	}
+
+	return desired
+}
+
+// PredictDesiredReplicas forecasts every metric in metrics using
+// predictor and the history recorded in store, and returns the desired
+// replica count each metric's forecast implies, keyed by metric name.
+// A metric whose history isn't long enough yet to produce a forecast is
+// skipped rather than failing the whole sync, since the other metrics
+// can still drive a scaling decision.
+func PredictDesiredReplicas(store *ObservationStore, predictor Predictor, hpaUID string, metrics []MetricTarget, currentReplicas int32, currentTime time.Time, pit float64) map[string]MetricPredictionResult {
+	results := make(map[string]MetricPredictionResult, len(metrics))
+
+	for _, metric := range metrics {
+		history := store.History(metricObservationKey(hpaUID, metric.Name))
+
+		predictedValue, err := predictor.Predict(history, currentTime, pit)
+		if err != nil {
+			continue
+		}
+
+		results[metric.Name] = MetricPredictionResult{
+			MetricName:      metric.Name,
+			PredictedValue:  predictedValue,
+			DesiredReplicas: desiredReplicasForMetric(currentReplicas, predictedValue, metric.TargetUtilization),
+		}
+	}
+
+	return results
+}
+
+// DesiredReplicasAcrossMetrics returns the largest desired replica count
+// implied by any one metric's forecast, mirroring how the non-predictive
+// HPA path reconciles multiple metrics: the most demanding metric wins.
+// The second return value is false when perMetricDesired is empty (e.g.
+// every metric was skipped in PredictDesiredReplicas for lacking
+// history) or when no metric ever yielded a result, in which case the
+// int32 is meaningless and callers should fall back to the current
+// replica count rather than treat it as "scale to zero". Per-metric
+// results are already floored at 0 by desiredReplicasForMetric, so the
+// max taken here is never negative.
+func DesiredReplicasAcrossMetrics(perMetricDesired map[string]MetricPredictionResult) (int32, bool) {
+	var desired int32
+	haveResult := false
+
+	for _, result := range perMetricDesired {
+		if !haveResult || result.DesiredReplicas > desired {
+			desired = result.DesiredReplicas
+			haveResult = true
+		}
+	}
+
+	return desired, haveResult
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podautoscaler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"k8s.io/kubernetes/pkg/apis/extensions"
+)
+
+func TestPredictiveMetricNamesNoAnnotation(t *testing.T) {
+	hpa := &extensions.HorizontalPodAutoscaler{}
+
+	metricNames, err := predictiveMetricNames(hpa)
+	assert.Nil(t, err)
+	assert.Nil(t, metricNames)
+}
+
+func TestPredictiveMetricNamesParsesList(t *testing.T) {
+	hpa := &extensions.HorizontalPodAutoscaler{}
+	writeToHPAAnnotations(hpa, PredictiveMetricsAnnotationName, `["cpu", "queue_depth"]`)
+
+	metricNames, err := predictiveMetricNames(hpa)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"cpu", "queue_depth"}, metricNames)
+}
+
+func TestPredictiveMetricNamesInvalidJSON(t *testing.T) {
+	hpa := &extensions.HorizontalPodAutoscaler{}
+	writeToHPAAnnotations(hpa, PredictiveMetricsAnnotationName, `not-json`)
+
+	_, err := predictiveMetricNames(hpa)
+	assert.NotNil(t, err, "Should error on malformed annotation value.")
+}
+
+func TestPredictDesiredReplicasPerMetric(t *testing.T) {
+	store := NewObservationStore(10, nil)
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		sampleTime := now.Add(time.Duration(i) * time.Second)
+		store.Record(metricObservationKey("hpa-1", "cpu"), Observation{Time: sampleTime, Value: 50.0})
+		store.Record(metricObservationKey("hpa-1", "queue_depth"), Observation{Time: sampleTime, Value: 200.0})
+	}
+
+	metrics := []MetricTarget{
+		{Name: "cpu", TargetUtilization: 50.0},
+		{Name: "queue_depth", TargetUtilization: 100.0},
+	}
+
+	results := PredictDesiredReplicas(store, NewLinearRegressionPredictor(), "hpa-1", metrics, 2, now.Add(3*time.Second), 5.0)
+
+	assert.Equal(t, 2, len(results), "Both metrics have history and should produce a result.")
+	assert.True(t, results["queue_depth"].DesiredReplicas > results["cpu"].DesiredReplicas, "queue_depth is twice its target and should demand more replicas than cpu at target.")
+}
+
+func TestPredictDesiredReplicasSkipsMetricsWithoutHistory(t *testing.T) {
+	store := NewObservationStore(10, nil)
+
+	metrics := []MetricTarget{
+		{Name: "cpu", TargetUtilization: 50.0},
+	}
+
+	results := PredictDesiredReplicas(store, NewLinearRegressionPredictor(), "hpa-1", metrics, 2, time.Now(), 5.0)
+	assert.Equal(t, 0, len(results), "A metric with no recorded observations should be skipped, not error.")
+}
+
+func TestDesiredReplicasAcrossMetricsTakesMax(t *testing.T) {
+	perMetric := map[string]MetricPredictionResult{
+		"cpu":         {DesiredReplicas: 3},
+		"queue_depth": {DesiredReplicas: 7},
+	}
+
+	desired, ok := DesiredReplicasAcrossMetrics(perMetric)
+	assert.True(t, ok)
+	assert.Equal(t, int32(7), desired)
+}
+
+func TestDesiredReplicasAcrossMetricsEmptyIsNotOK(t *testing.T) {
+	desired, ok := DesiredReplicasAcrossMetrics(map[string]MetricPredictionResult{})
+	assert.False(t, ok, "An empty result set should not be mistaken for a 'scale to zero' decision.")
+	assert.Equal(t, int32(0), desired)
+}
+
+func TestDesiredReplicasForMetricFloorsAtZero(t *testing.T) {
+	// A predicted utilization far below target on a declining trend
+	// would otherwise imply a negative replica count, which is never a
+	// valid scaling target.
+	desired := desiredReplicasForMetric(4, -100.0, 50.0)
+	assert.Equal(t, int32(0), desired, "A negative implied replica count should be floored at zero, not propagated.")
+}
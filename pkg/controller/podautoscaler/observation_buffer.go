@@ -0,0 +1,161 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podautoscaler
+
+import "sync"
+
+// ObservationPersister is an optional hook that lets observation history
+// survive controller restarts. Implementations might write through to a
+// ConfigMap, local disk, or an external time-series store; the in-memory
+// ObservationStore works fine without one.
+type ObservationPersister interface {
+	// Save persists the full observation history for the given HPA.
+	Save(hpaUID string, history []Observation) error
+	// Load returns the previously persisted observation history for
+	// the given HPA, or an empty slice if none exists.
+	Load(hpaUID string) ([]Observation, error)
+}
+
+// ObservationBuffer is a fixed-size ring buffer of observations for a
+// single HPA. It replaces the previous approach of JSON-encoding
+// observations into the HPA's annotations, which is both capped by the
+// 256KB annotation size limit and churns the HPA object on every sync.
+type ObservationBuffer struct {
+	mu       sync.Mutex
+	capacity int
+	data     []Observation
+	start    int
+	size     int
+}
+
+// NewObservationBuffer creates an empty ring buffer that holds at most
+// capacity observations, discarding the oldest once full. A non-positive
+// capacity is clamped to 1 rather than left to panic on the first Add.
+func NewObservationBuffer(capacity int) *ObservationBuffer {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &ObservationBuffer{
+		capacity: capacity,
+		data:     make([]Observation, capacity),
+	}
+}
+
+// Add records a new observation, evicting the oldest one if the buffer
+// is already at capacity.
+func (b *ObservationBuffer) Add(obs Observation) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	writeIdx := (b.start + b.size) % b.capacity
+	b.data[writeIdx] = obs
+
+	if b.size < b.capacity {
+		b.size++
+	} else {
+		b.start = (b.start + 1) % b.capacity
+	}
+}
+
+// Snapshot returns the buffered observations in chronological order.
+func (b *ObservationBuffer) Snapshot() []Observation {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	out := make([]Observation, b.size)
+	for i := 0; i < b.size; i++ {
+		out[i] = b.data[(b.start+i)%b.capacity]
+	}
+
+	return out
+}
+
+// Seed replaces the buffer's contents with a previously persisted
+// history, keeping only the most recent `capacity` observations.
+func (b *ObservationBuffer) Seed(history []Observation) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(history) > b.capacity {
+		history = history[len(history)-b.capacity:]
+	}
+
+	b.start = 0
+	b.size = len(history)
+	copy(b.data, history)
+}
+
+// ObservationStore keeps a per-HPA ObservationBuffer in memory and
+// optionally writes through to an ObservationPersister so history is not
+// lost across controller restarts.
+type ObservationStore struct {
+	mu        sync.Mutex
+	capacity  int
+	buffers   map[string]*ObservationBuffer
+	persister ObservationPersister
+}
+
+// NewObservationStore creates a store whose per-HPA buffers hold up to
+// capacity observations. persister may be nil, in which case history
+// does not survive a controller restart.
+func NewObservationStore(capacity int, persister ObservationPersister) *ObservationStore {
+	return &ObservationStore{
+		capacity:  capacity,
+		buffers:   make(map[string]*ObservationBuffer),
+		persister: persister,
+	}
+}
+
+// Record adds obs to the buffer for hpaUID, seeding the buffer from the
+// persister (if any) the first time hpaUID is seen, and writes through
+// to the persister after recording.
+func (s *ObservationStore) Record(hpaUID string, obs Observation) error {
+	buffer := s.bufferFor(hpaUID)
+	buffer.Add(obs)
+
+	if s.persister == nil {
+		return nil
+	}
+
+	return s.persister.Save(hpaUID, buffer.Snapshot())
+}
+
+// History returns the buffered observations for hpaUID in chronological
+// order.
+func (s *ObservationStore) History(hpaUID string) []Observation {
+	return s.bufferFor(hpaUID).Snapshot()
+}
+
+func (s *ObservationStore) bufferFor(hpaUID string) *ObservationBuffer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if buffer, found := s.buffers[hpaUID]; found {
+		return buffer
+	}
+
+	buffer := NewObservationBuffer(s.capacity)
+	if s.persister != nil {
+		if history, err := s.persister.Load(hpaUID); err == nil {
+			buffer.Seed(history)
+		}
+	}
+
+	s.buffers[hpaUID] = buffer
+	return buffer
+}
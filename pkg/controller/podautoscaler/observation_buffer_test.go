@@ -0,0 +1,120 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podautoscaler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObservationBufferEvictsOldest(t *testing.T) {
+	buffer := NewObservationBuffer(3)
+	now := time.Now()
+
+	for i := 0; i < 5; i++ {
+		buffer.Add(Observation{Time: now.Add(time.Duration(i) * time.Second), Value: float64(i)})
+	}
+
+	snapshot := buffer.Snapshot()
+	assert.Equal(t, 3, len(snapshot), "Buffer should never grow past its capacity.")
+	assert.Equal(t, 2.0, snapshot[0].Value, "Oldest observations should be evicted first.")
+	assert.Equal(t, 4.0, snapshot[2].Value, "Most recent observation should be last.")
+}
+
+func TestObservationBufferClampsNonPositiveCapacity(t *testing.T) {
+	buffer := NewObservationBuffer(0)
+	now := time.Now()
+
+	buffer.Add(Observation{Time: now, Value: 1.0})
+	buffer.Add(Observation{Time: now.Add(time.Second), Value: 2.0})
+
+	snapshot := buffer.Snapshot()
+	assert.Equal(t, 1, len(snapshot), "A non-positive capacity should be clamped to 1, not left to panic on Add.")
+	assert.Equal(t, 2.0, snapshot[0].Value)
+}
+
+func TestObservationBufferSeedTrimsToCapacity(t *testing.T) {
+	buffer := NewObservationBuffer(2)
+	now := time.Now()
+
+	history := []Observation{
+		{Time: now, Value: 1.0},
+		{Time: now.Add(time.Second), Value: 2.0},
+		{Time: now.Add(2 * time.Second), Value: 3.0},
+	}
+
+	buffer.Seed(history)
+
+	snapshot := buffer.Snapshot()
+	assert.Equal(t, 2, len(snapshot), "Seeding should trim to the buffer's capacity.")
+	assert.Equal(t, 2.0, snapshot[0].Value, "Should keep the most recent observations.")
+	assert.Equal(t, 3.0, snapshot[1].Value, "Should keep the most recent observations.")
+}
+
+type fakePersister struct {
+	saved map[string][]Observation
+}
+
+func newFakePersister() *fakePersister {
+	return &fakePersister{saved: make(map[string][]Observation)}
+}
+
+func (f *fakePersister) Save(hpaUID string, history []Observation) error {
+	f.saved[hpaUID] = history
+	return nil
+}
+
+func (f *fakePersister) Load(hpaUID string) ([]Observation, error) {
+	history, found := f.saved[hpaUID]
+	if !found {
+		return []Observation{}, nil
+	}
+	return history, nil
+}
+
+func TestObservationStoreWritesThroughToPersister(t *testing.T) {
+	persister := newFakePersister()
+	store := NewObservationStore(10, persister)
+
+	err := store.Record("hpa-1", Observation{Time: time.Now(), Value: 42.0})
+	assert.Nil(t, err)
+
+	assert.Equal(t, 1, len(persister.saved["hpa-1"]), "Recording should write through to the persister.")
+}
+
+func TestObservationStoreSeedsFromPersisterOnFirstUse(t *testing.T) {
+	persister := newFakePersister()
+	now := time.Now()
+	persister.saved["hpa-1"] = []Observation{{Time: now, Value: 10.0}}
+
+	store := NewObservationStore(10, persister)
+
+	history := store.History("hpa-1")
+	assert.Equal(t, 1, len(history), "A new store should seed its buffer from the persister.")
+	assert.Equal(t, 10.0, history[0].Value)
+}
+
+func TestObservationStoreWithoutPersister(t *testing.T) {
+	store := NewObservationStore(10, nil)
+
+	err := store.Record("hpa-1", Observation{Time: time.Now(), Value: 5.0})
+	assert.Nil(t, err, "Recording without a persister should not error.")
+
+	assert.Equal(t, 1, len(store.History("hpa-1")))
+}
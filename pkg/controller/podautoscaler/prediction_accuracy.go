@@ -0,0 +1,216 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podautoscaler
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"k8s.io/kubernetes/pkg/apis/extensions"
+)
+
+const (
+	// defaultAccuracyWindowSize is the number of recent forecast
+	// errors a model's rolling MAPE is computed over.
+	defaultAccuracyWindowSize = 20
+
+	// defaultMAPEFallbackThreshold is the rolling MAPE, as a fraction,
+	// above which a model is considered unreliable enough to fall
+	// back to the non-predictive path.
+	defaultMAPEFallbackThreshold = 0.5
+)
+
+var (
+	predictionErrorMAPE = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hpa_prediction_error_mape",
+			Help: "Rolling mean absolute percentage error of a predictive HPA's forecasts, by HPA and model.",
+		},
+		[]string{"namespace", "name", "model"},
+	)
+
+	predictionHorizonSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hpa_prediction_horizon_seconds",
+			Help: "How far ahead, in seconds, a predictive HPA's most recent forecast looked.",
+		},
+		[]string{"namespace", "name", "model"},
+	)
+
+	predictionModel = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hpa_prediction_model",
+			Help: "1 for the model currently active on an HPA, 0 otherwise.",
+		},
+		[]string{"namespace", "name", "model"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(predictionErrorMAPE)
+	prometheus.MustRegister(predictionHorizonSeconds)
+	prometheus.MustRegister(predictionModel)
+}
+
+// pendingForecast is a forecast made for a specific point in time, kept
+// around until that time is actually observed so it can be scored.
+type pendingForecast struct {
+	forTime time.Time
+	value   float64
+}
+
+// modelAccuracy is the rolling error window and fallback state for a
+// single HPA/model pair.
+type modelAccuracy struct {
+	pending       *pendingForecast
+	percentErrors []float64
+	squaredErrors []float64
+	fallenBack    bool
+}
+
+func (m *modelAccuracy) mape() float64 {
+	return rollingMean(m.percentErrors)
+}
+
+func (m *modelAccuracy) rmse() float64 {
+	return math.Sqrt(rollingMean(m.squaredErrors))
+}
+
+// PredictionAccuracyStore tracks, per HPA and per predictive model,
+// how close recent forecasts came to what was actually observed. It
+// exports the rolling error as Prometheus metrics and recommends
+// falling back to the non-predictive path when a model's accuracy gets
+// too poor to trust.
+type PredictionAccuracyStore struct {
+	mu              sync.Mutex
+	windowSize      int
+	fallbackMAPE    float64
+	accuracyByModel map[string]map[PredictorModel]*modelAccuracy
+}
+
+// NewPredictionAccuracyStore creates a store whose rolling MAPE/RMSE
+// windows hold windowSize samples, and which recommends falling back
+// to the non-predictive path once a model's rolling MAPE exceeds
+// fallbackMAPE (a fraction, e.g. 0.5 for 50%).
+func NewPredictionAccuracyStore(windowSize int, fallbackMAPE float64) *PredictionAccuracyStore {
+	return &PredictionAccuracyStore{
+		windowSize:      windowSize,
+		fallbackMAPE:    fallbackMAPE,
+		accuracyByModel: make(map[string]map[PredictorModel]*modelAccuracy),
+	}
+}
+
+// RecordForecast remembers that model forecast value for forTime on
+// behalf of hpa, and publishes the look-ahead horizon that produced it.
+// The forecast is scored the next time Observe is called with an actual
+// value at or after forTime.
+func (s *PredictionAccuracyStore) RecordForecast(hpa *extensions.HorizontalPodAutoscaler, model PredictorModel, forTime time.Time, value float64, horizon time.Duration) {
+	accuracy := s.accuracyFor(hpa, model)
+
+	s.mu.Lock()
+	accuracy.pending = &pendingForecast{forTime: forTime, value: value}
+	s.mu.Unlock()
+
+	predictionHorizonSeconds.WithLabelValues(hpa.Namespace, hpa.Name, string(model)).Set(horizon.Seconds())
+}
+
+// Observe scores model's pending forecast (if any) against actualValue
+// observed at actualTime, folds the error into the rolling MAPE/RMSE
+// window, republishes the Prometheus metrics, and reports whether this
+// HPA should currently be run through the non-predictive path because
+// model's rolling MAPE has exceeded the configured threshold.
+func (s *PredictionAccuracyStore) Observe(hpa *extensions.HorizontalPodAutoscaler, model PredictorModel, actualTime time.Time, actualValue float64) bool {
+	accuracy := s.accuracyFor(hpa, model)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if accuracy.pending != nil && !accuracy.pending.forTime.After(actualTime) {
+		predicted := accuracy.pending.value
+		accuracy.pending = nil
+
+		if actualValue != 0 {
+			percentError := math.Abs(predicted-actualValue) / math.Abs(actualValue)
+			accuracy.percentErrors = appendCapped(accuracy.percentErrors, percentError, s.windowSize)
+		}
+
+		squaredError := (predicted - actualValue) * (predicted - actualValue)
+		accuracy.squaredErrors = appendCapped(accuracy.squaredErrors, squaredError, s.windowSize)
+
+		predictionErrorMAPE.WithLabelValues(hpa.Namespace, hpa.Name, string(model)).Set(accuracy.mape())
+	}
+
+	accuracy.fallenBack = len(accuracy.percentErrors) > 0 && accuracy.mape() > s.fallbackMAPE
+
+	activeValue := 1.0
+	if accuracy.fallenBack {
+		activeValue = 0.0
+	}
+	predictionModel.WithLabelValues(hpa.Namespace, hpa.Name, string(model)).Set(activeValue)
+
+	return accuracy.fallenBack
+}
+
+func (s *PredictionAccuracyStore) accuracyFor(hpa *extensions.HorizontalPodAutoscaler, model PredictorModel) *modelAccuracy {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := hpaKey(hpa)
+	models, found := s.accuracyByModel[key]
+	if !found {
+		models = make(map[PredictorModel]*modelAccuracy)
+		s.accuracyByModel[key] = models
+	}
+
+	accuracy, found := models[model]
+	if !found {
+		accuracy = &modelAccuracy{}
+		models[model] = accuracy
+	}
+
+	return accuracy
+}
+
+// hpaKey identifies an HPA for accuracy-tracking purposes.
+func hpaKey(hpa *extensions.HorizontalPodAutoscaler) string {
+	return hpa.Namespace + "/" + hpa.Name
+}
+
+func rollingMean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+
+	return sum / float64(len(values))
+}
+
+func appendCapped(values []float64, v float64, capacity int) []float64 {
+	values = append(values, v)
+	if len(values) > capacity {
+		values = values[len(values)-capacity:]
+	}
+
+	return values
+}
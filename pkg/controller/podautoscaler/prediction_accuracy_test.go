@@ -0,0 +1,107 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podautoscaler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/apis/extensions"
+)
+
+func testHPA(name string) *extensions.HorizontalPodAutoscaler {
+	return &extensions.HorizontalPodAutoscaler{
+		ObjectMeta: api.ObjectMeta{Namespace: "default", Name: name},
+	}
+}
+
+func TestObserveWithNoPendingForecastDoesNotFallBack(t *testing.T) {
+	store := NewPredictionAccuracyStore(defaultAccuracyWindowSize, defaultMAPEFallbackThreshold)
+	hpa := testHPA("no-forecast-yet")
+
+	fellBack := store.Observe(hpa, LinearRegressionModel, time.Now(), 50.0)
+	assert.False(t, fellBack, "An HPA with no pending forecast should not be judged yet.")
+}
+
+func TestObserveAccurateForecastsDoNotFallBack(t *testing.T) {
+	store := NewPredictionAccuracyStore(defaultAccuracyWindowSize, defaultMAPEFallbackThreshold)
+	hpa := testHPA("accurate")
+	now := time.Now()
+
+	for i := 0; i < 5; i++ {
+		forecastTime := now.Add(time.Duration(i) * time.Minute)
+		store.RecordForecast(hpa, LinearRegressionModel, forecastTime, 50.0, 5*time.Minute)
+
+		fellBack := store.Observe(hpa, LinearRegressionModel, forecastTime, 51.0)
+		assert.False(t, fellBack, "Forecasts within a couple percent of actual should not trip the fallback.")
+	}
+}
+
+func TestObserveInaccurateForecastsTriggerFallback(t *testing.T) {
+	store := NewPredictionAccuracyStore(defaultAccuracyWindowSize, defaultMAPEFallbackThreshold)
+	hpa := testHPA("inaccurate")
+	now := time.Now()
+
+	var fellBack bool
+	for i := 0; i < 5; i++ {
+		forecastTime := now.Add(time.Duration(i) * time.Minute)
+		store.RecordForecast(hpa, DSPModel, forecastTime, 100.0, 5*time.Minute)
+
+		fellBack = store.Observe(hpa, DSPModel, forecastTime, 10.0)
+	}
+
+	assert.True(t, fellBack, "Forecasts off by 10x should exceed the rolling MAPE threshold and trigger fallback.")
+}
+
+func TestObserveRecoversAfterFallback(t *testing.T) {
+	store := NewPredictionAccuracyStore(3, defaultMAPEFallbackThreshold)
+	hpa := testHPA("recovers")
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		forecastTime := now.Add(time.Duration(i) * time.Minute)
+		store.RecordForecast(hpa, HoltWintersModel, forecastTime, 100.0, 5*time.Minute)
+		store.Observe(hpa, HoltWintersModel, forecastTime, 10.0)
+	}
+
+	for i := 3; i < 6; i++ {
+		forecastTime := now.Add(time.Duration(i) * time.Minute)
+		store.RecordForecast(hpa, HoltWintersModel, forecastTime, 50.0, 5*time.Minute)
+		store.Observe(hpa, HoltWintersModel, forecastTime, 50.0)
+	}
+
+	fellBack := store.Observe(hpa, HoltWintersModel, now.Add(6*time.Minute), 50.0)
+	assert.False(t, fellBack, "Once the rolling window fills with accurate forecasts, the HPA should recover.")
+}
+
+func TestModelsAreTrackedIndependently(t *testing.T) {
+	store := NewPredictionAccuracyStore(defaultAccuracyWindowSize, defaultMAPEFallbackThreshold)
+	hpa := testHPA("independent-models")
+	now := time.Now()
+
+	store.RecordForecast(hpa, DSPModel, now, 100.0, time.Minute)
+	dspFellBack := store.Observe(hpa, DSPModel, now, 10.0)
+
+	store.RecordForecast(hpa, LinearRegressionModel, now, 50.0, time.Minute)
+	linRegFellBack := store.Observe(hpa, LinearRegressionModel, now, 51.0)
+
+	assert.True(t, dspFellBack, "A wildly inaccurate model should fall back.")
+	assert.False(t, linRegFellBack, "An accurate model for the same HPA should be unaffected by another model's errors.")
+}
@@ -18,7 +18,6 @@ package podautoscaler
 
 import (
 	"fmt"
-	"math"
 	"time"
 
 	"github.com/montanaflynn/stats"
@@ -35,11 +34,6 @@ const (
 	// PredictiveAutoscalingAnnotationName must have a value of "true" in
 	// the annotations hash to enable predictive auto-scaling.
 	PredictiveAutoscalingAnnotationName = "predictive"
-
-	// PreviousCPUAnnotationName is the name in which we store a JSON map of
-	// previous CPU utilization observations and the time at which they
-	// occured.
-	PreviousCPUAnnotationName = "previousCPUUtilizations"
 )
 
 // isPredictive is a helper function for checking if this auto-scaler is
@@ -53,60 +47,6 @@ func isPredictive(hpa *extensions.HorizontalPodAutoscaler) bool {
 	return false
 }
 
-// updateUtilizationObservations takes the current utilization, the previous
-// observations, and the pod initialization time and adds the current
-// observation to the previous observations to return the observations that
-// should be recorded in the auto-scaler object.
-func updateUtilizationObservations(cpuCurrentUtilization int, previousObservations []map[string]int, podInitTime float64) ([]map[string]int, error) {
-	jsonTime, err := time.Now().MarshalJSON()
-	if err != nil {
-		return nil, err
-	}
-
-	observation := map[string]int{string(jsonTime[:]): cpuCurrentUtilization}
-	previousObservations = removeOldUtils(previousObservations, podInitTime)
-
-	updatedObservations := append(previousObservations, observation)
-	return updatedObservations, nil
-}
-
-// removeOldUtils removes any previous CPU observations that we no longer wish
-// to record.
-func removeOldUtils(previousObservations []map[string]int, podInitTime float64) []map[string]int {
-	k := 20.0
-	// Don't predict on more than 10 minutes - given a 30 second sync
-	// period, this is a maximum of 20 stored observations.
-	maxDistance := math.Min(podInitTime*k, 60.0*10.0)
-	firstToKeep := -1
-	stop := false
-	var t time.Time
-
-	for i, timeMap := range previousObservations {
-		if stop {
-			break
-		}
-
-		for key := range timeMap {
-			t.UnmarshalJSON([]byte(key))
-
-			// We add new observations to the end, so we are looking
-			// for the first observation within the range we want,
-			// and we are guaranteed that all after it will also be
-			// in the range.
-			if time.Since(t).Seconds() < maxDistance {
-				firstToKeep = i
-				stop = true
-			}
-		}
-	}
-
-	if firstToKeep == -1 {
-		return []map[string]int{}
-	}
-
-	return previousObservations[firstToKeep:]
-}
-
 // initTimeForPods returns the average initialization time for all of these
 // pods.
 func initTimeForPods(pods []api.Pod) (float64, error) {
@@ -153,24 +93,20 @@ func initTimeForPod(pod api.Pod) (float64, error) {
 	return 0.0, fmt.Errorf("Pod is not ready.")
 }
 
-// getSecondsAndCPULists takes what was recorded in `hpa.Annotations` and
-// returns two separate lists of seconds and CPU Util lists which can be through
-// of as the x and y values respective.
-func getSecondsAndCPULists(previousUtils []map[string]int) ([]float64, []float64) {
-	allSeconds := []float64{}
-	allCPUUtilizations := []float64{}
-
-	for _, obs := range previousUtils {
-		for obsTime, cpuUtil := range obs {
-			var t time.Time
-			t.UnmarshalJSON([]byte(obsTime))
-
-			allSeconds = append(allSeconds, float64(t.Unix()))
-			allCPUUtilizations = append(allCPUUtilizations, float64(cpuUtil))
-		}
+// secondsAndValuesFromObservations splits an observation history into
+// parallel lists of Unix seconds and values, which can be thought of as
+// the x and y values respectively. This is the shape the regression
+// helpers below operate on.
+func secondsAndValuesFromObservations(history []Observation) ([]float64, []float64) {
+	allSeconds := make([]float64, len(history))
+	allValues := make([]float64, len(history))
+
+	for i, obs := range history {
+		allSeconds[i] = float64(obs.Time.Unix())
+		allValues[i] = obs.Value
 	}
 
-	return allSeconds, allCPUUtilizations
+	return allSeconds, allValues
 }
 
 // lineOfBestFit is a helper method for calculating the line of best fit for cpu
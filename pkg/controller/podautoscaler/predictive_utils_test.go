@@ -28,113 +28,6 @@ import (
 	"k8s.io/kubernetes/pkg/api/unversioned"
 )
 
-func TestUpdateUtilizationObservationsNoPrevious(t *testing.T) {
-	// Start with no previous observations and add a single observation.
-	cpuUtil := 70
-	previousObs := []map[string]int{}
-	podInit := 5.0
-
-	currentTime, err := time.Now().MarshalJSON()
-	assert.Nil(t, err)
-
-	recordedObs, err := updateUtilizationObservations(cpuUtil, string(currentTime[:]), previousObs, podInit)
-	assert.Nil(t, err)
-
-	assert.Equal(t, len(recordedObs), 1, "Should have added one observation.")
-
-	for _, value := range recordedObs[0] {
-		assert.Equal(t, value, cpuUtil, "Should record CPU util as value in map.")
-	}
-}
-
-func TestUpdateUtilizationObservationsOnePrevious(t *testing.T) {
-	// Start with one previous observation.
-	timeNow, err := time.Now().Add(-5 * time.Second).MarshalJSON()
-	assert.Nil(t, err)
-
-	previousObs := []map[string]int{{string(timeNow[:]): 50}}
-
-	cpuUtil := 70
-	podInit := 5.0
-
-	currentTime, err := time.Now().MarshalJSON()
-	assert.Nil(t, err)
-
-	recordedObs, err := updateUtilizationObservations(cpuUtil, string(currentTime[:]), previousObs, podInit)
-	assert.Nil(t, err)
-
-	assert.Equal(t, len(recordedObs), 2, "Should have a total of two observations.")
-}
-
-func TestUpdateUtilizationsObservationsRemoveAllPrevious(t *testing.T) {
-	// Start with one previous observation from 11 minutes ago that will be
-	// removed (because the cutoff is 10 minutes).
-	oldTime, err := time.Now().Add(-11 * time.Minute).MarshalJSON()
-	assert.Nil(t, err)
-
-	previousObs := []map[string]int{{string(oldTime[:]): 50}}
-
-	cpuUtil := 70
-	podInit := 5.0
-
-	currentTime, err := time.Now().MarshalJSON()
-	assert.Nil(t, err)
-
-	recordedObs, err := updateUtilizationObservations(cpuUtil, string(currentTime[:]), previousObs, podInit)
-	assert.Nil(t, err)
-
-	assert.Equal(t, len(recordedObs), 1, "Only one observation should remain.")
-}
-
-func TestUpdateUtilizationsObservationsRemoveSomePrevious(t *testing.T) {
-	// Start with one previous observation from 11 minutes ago that will be
-	// removed (because the cutoff is 10 minutes).
-	oldTime, err := time.Now().Add(-11 * time.Minute).MarshalJSON()
-	assert.Nil(t, err)
-
-	lessOldTime, err := time.Now().Add(-1 * time.Minute).MarshalJSON()
-	assert.Nil(t, err)
-
-	previousObs := []map[string]int{
-		{string(oldTime[:]): 50},
-		{string(lessOldTime[:]): 10},
-	}
-
-	cpuUtil := 70
-	podInit := 5.0
-
-	currentTime, err := time.Now().MarshalJSON()
-	assert.Nil(t, err)
-
-	recordedObs, err := updateUtilizationObservations(cpuUtil, string(currentTime[:]), previousObs, podInit)
-	assert.Nil(t, err)
-
-	assert.Equal(t, len(recordedObs), 2, "Only one observation should be removed.")
-}
-
-// TestUpdateUtilizationsObservationsRemoveReplicas tests that we do not
-// write any observations that are direct replicas of what we previously
-// recorded.
-func TestUpdateUtilizationsObservationsRemoveReplicas(t *testing.T) {
-	oldTime, err := time.Now().Add(-1 * time.Minute).MarshalJSON()
-	assert.Nil(t, err)
-
-	replicaTime := oldTime
-
-	cpuUtil := 70
-	podInit := 5.0
-
-	previousObs := []map[string]int{
-		{string(oldTime[:]): 50},
-	}
-
-	recordedObs, err := updateUtilizationObservations(cpuUtil, string(replicaTime[:]), previousObs, podInit)
-	assert.Nil(t, err)
-
-	assert.Equal(t, len(recordedObs), 1, "Should not make a duplicate observation.")
-
-}
-
 func TestInitTimeForPods(t *testing.T) {
 	testPods := createTestPods()
 
@@ -144,19 +37,19 @@ func TestInitTimeForPods(t *testing.T) {
 	assert.True(t, initTime > 0.0, "Act. init time should be desired init time.")
 }
 
-func TestGetSecondsAndCPULists(t *testing.T) {
-	currentTime, err := time.Now().MarshalJSON()
-	assert.Nil(t, err)
+func TestSecondsAndValuesFromObservations(t *testing.T) {
+	now := time.Now()
 
-	previousUtils := []map[string]int{
-		{string(currentTime[:]): 50.0},
-		{string(currentTime[:]): 60.0},
+	history := []Observation{
+		{Time: now, Value: 50.0},
+		{Time: now.Add(30 * time.Second), Value: 60.0},
 	}
 
-	xVals, yVals := getSecondsAndCPULists(previousUtils)
+	xVals, yVals := secondsAndValuesFromObservations(history)
 
 	assert.Equal(t, len(xVals), 2, "There should be two time values.")
-	assert.Equal(t, len(yVals), 2, "There should be two CPU utilizations")
+	assert.Equal(t, len(yVals), 2, "There should be two observation values.")
+	assert.Equal(t, yVals[1], 60.0, "Values should be returned in order.")
 }
 
 func TestLineOfBestFit(t *testing.T) {
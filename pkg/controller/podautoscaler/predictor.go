@@ -0,0 +1,455 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podautoscaler
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sort"
+	"time"
+
+	"github.com/montanaflynn/stats"
+	"gonum.org/v1/gonum/dsp/fourier"
+)
+
+// Observation is a single sample of a metric's value at a point in time.
+type Observation struct {
+	Time  time.Time
+	Value float64
+}
+
+// Predictor forecasts the value of a metric `pit` seconds past
+// currentTime, given a history of past observations. Implementations are
+// free to require a minimum history length or sampling regularity and
+// should return an error rather than a low-confidence guess when that
+// requirement isn't met.
+type Predictor interface {
+	Predict(history []Observation, currentTime time.Time, pit float64) (float64, error)
+}
+
+// SeriesPredictor is implemented by predictors that can amortize a
+// single fit of history across several forecast points. Callers that
+// need the forecast at many points in time, such as
+// ShouldDampenScaleDown sampling across its horizon, should prefer this
+// over repeated Predict calls when a predictor supports it: for
+// DSPPredictor in particular, each Predict call redoes the resample,
+// autocorrelation, and FFT from scratch even though only pit changes.
+type SeriesPredictor interface {
+	PredictSeries(history []Observation, currentTime time.Time, pits []float64) ([]float64, error)
+}
+
+// PredictorModel identifies which Predictor implementation an HPA has
+// opted into.
+type PredictorModel string
+
+const (
+	// LinearRegressionModel fits a straight line through the
+	// observation history. This is the original predictive algorithm.
+	LinearRegressionModel PredictorModel = "linreg"
+
+	// HoltWintersModel applies triple exponential smoothing, tracking
+	// level, trend, and a repeating seasonal pattern.
+	HoltWintersModel PredictorModel = "holtwinters"
+
+	// DSPModel reconstructs a forecast from the dominant frequency
+	// components of the observation history.
+	DSPModel PredictorModel = "dsp"
+)
+
+// LinearRegressionPredictor is the original predictive auto-scaling
+// algorithm: it fits a line of best fit through the history and
+// extrapolates it to currentTime+pit.
+type LinearRegressionPredictor struct{}
+
+// NewLinearRegressionPredictor creates a LinearRegressionPredictor.
+func NewLinearRegressionPredictor() *LinearRegressionPredictor {
+	return &LinearRegressionPredictor{}
+}
+
+// Predict implements Predictor.
+func (p *LinearRegressionPredictor) Predict(history []Observation, currentTime time.Time, pit float64) (float64, error) {
+	if len(history) == 0 {
+		return 0, fmt.Errorf("cannot predict from an empty observation history")
+	}
+
+	seconds, values := secondsAndValuesFromObservations(history)
+
+	yIntercept, slope, err := lineOfBestFit(seconds, values)
+	if err != nil {
+		return 0, err
+	}
+
+	return predictFutureCPUFromBestFit(pit, float64(currentTime.Unix()), *yIntercept, *slope), nil
+}
+
+// HoltWintersPredictor forecasts using triple exponential smoothing,
+// which models a level, a trend, and a repeating seasonal pattern. It
+// captures daily/weekly load cycles that LinearRegressionPredictor
+// cannot.
+type HoltWintersPredictor struct {
+	// Alpha is the level smoothing factor, in (0, 1).
+	Alpha float64
+	// Beta is the trend smoothing factor, in (0, 1).
+	Beta float64
+	// Gamma is the seasonal smoothing factor, in (0, 1).
+	Gamma float64
+	// SeasonLength is the number of observations in one seasonal cycle.
+	SeasonLength int
+}
+
+// NewHoltWintersPredictor creates a HoltWintersPredictor with the given
+// smoothing factors and season length.
+func NewHoltWintersPredictor(alpha, beta, gamma float64, seasonLength int) *HoltWintersPredictor {
+	return &HoltWintersPredictor{Alpha: alpha, Beta: beta, Gamma: gamma, SeasonLength: seasonLength}
+}
+
+// Predict implements Predictor.
+func (p *HoltWintersPredictor) Predict(history []Observation, currentTime time.Time, pit float64) (float64, error) {
+	if p.SeasonLength <= 0 {
+		return 0, fmt.Errorf("season length must be positive, got %d", p.SeasonLength)
+	}
+
+	if len(history) < 2*p.SeasonLength {
+		return 0, fmt.Errorf("need at least %d observations for a season length of %d, have %d", 2*p.SeasonLength, p.SeasonLength, len(history))
+	}
+
+	_, values := secondsAndValuesFromObservations(history)
+
+	level, trend, seasonal, err := holtWintersFit(values, p.Alpha, p.Beta, p.Gamma, p.SeasonLength)
+	if err != nil {
+		return 0, err
+	}
+
+	stepsAhead := stepsAheadFromHistory(history, currentTime, pit)
+	seasonalIndex := (len(values) - 1 + stepsAhead) % p.SeasonLength
+
+	return level + float64(stepsAhead)*trend + seasonal[seasonalIndex], nil
+}
+
+// holtWintersFit runs the standard triple exponential smoothing
+// recurrence over values and returns the final level, trend, and
+// per-season seasonal components.
+func holtWintersFit(values []float64, alpha, beta, gamma float64, seasonLength int) (float64, float64, []float64, error) {
+	seasonal := initialSeasonalIndices(values, seasonLength)
+
+	firstSeasonMean, err := stats.Mean(values[:seasonLength])
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	secondSeasonMean, err := stats.Mean(values[seasonLength : 2*seasonLength])
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	level := firstSeasonMean
+	trend := (secondSeasonMean - firstSeasonMean) / float64(seasonLength)
+
+	for i, v := range values {
+		seasonIdx := i % seasonLength
+		lastLevel := level
+
+		level = alpha*(v-seasonal[seasonIdx]) + (1-alpha)*(level+trend)
+		trend = beta*(level-lastLevel) + (1-beta)*trend
+		seasonal[seasonIdx] = gamma*(v-level) + (1-gamma)*seasonal[seasonIdx]
+	}
+
+	return level, trend, seasonal, nil
+}
+
+// initialSeasonalIndices estimates a starting seasonal component per
+// position in the cycle by averaging each season's deviation from its
+// own mean.
+func initialSeasonalIndices(values []float64, seasonLength int) []float64 {
+	seasons := len(values) / seasonLength
+	seasonal := make([]float64, seasonLength)
+	seasonAverages := make([]float64, seasons)
+
+	for s := 0; s < seasons; s++ {
+		sum := 0.0
+		for i := 0; i < seasonLength; i++ {
+			sum += values[s*seasonLength+i]
+		}
+		seasonAverages[s] = sum / float64(seasonLength)
+	}
+
+	for i := 0; i < seasonLength; i++ {
+		sum := 0.0
+		for s := 0; s < seasons; s++ {
+			sum += values[s*seasonLength+i] - seasonAverages[s]
+		}
+		seasonal[i] = sum / float64(seasons)
+	}
+
+	return seasonal
+}
+
+// stepsAheadFromHistory estimates how many sampling steps separate the
+// last observation in history from currentTime+pit, assuming
+// observations are roughly evenly spaced. currentTime is not assumed to
+// equal the last observation's timestamp — in a real controller there
+// is always a gap between the last recorded sample and when a sync
+// actually asks for a forecast, so that gap is folded in here.
+func stepsAheadFromHistory(history []Observation, currentTime time.Time, pit float64) int {
+	if len(history) < 2 {
+		return 1
+	}
+
+	totalSpan := history[len(history)-1].Time.Sub(history[0].Time).Seconds()
+	avgInterval := totalSpan / float64(len(history)-1)
+	if avgInterval <= 0 {
+		return 1
+	}
+
+	elapsedSinceLastObservation := currentTime.Sub(history[len(history)-1].Time).Seconds()
+	steps := int(math.Round((elapsedSinceLastObservation + pit) / avgInterval))
+	if steps < 1 {
+		steps = 1
+	}
+
+	return steps
+}
+
+// DSPPredictor treats the observation history as a signal, estimates its
+// dominant period via autocorrelation, and reconstructs a forecast from
+// the top-K frequency components whose amplitude exceeds NoiseThreshold.
+type DSPPredictor struct {
+	// SampleInterval is the fixed spacing the history is resampled to
+	// before running the FFT.
+	SampleInterval time.Duration
+	// TopK is the number of dominant frequency components to keep.
+	TopK int
+	// NoiseThreshold discards frequency components whose amplitude
+	// falls at or below this value.
+	NoiseThreshold float64
+}
+
+// NewDSPPredictor creates a DSPPredictor with the given resampling
+// interval, component count, and noise threshold.
+func NewDSPPredictor(sampleInterval time.Duration, topK int, noiseThreshold float64) *DSPPredictor {
+	return &DSPPredictor{SampleInterval: sampleInterval, TopK: topK, NoiseThreshold: noiseThreshold}
+}
+
+// Predict implements Predictor.
+func (p *DSPPredictor) Predict(history []Observation, currentTime time.Time, pit float64) (float64, error) {
+	forecasts, err := p.PredictSeries(history, currentTime, []float64{pit})
+	if err != nil {
+		return 0, err
+	}
+
+	return forecasts[0], nil
+}
+
+// dspComponent is a single frequency component kept from the FFT: its
+// frequency, amplitude, and phase are all that's needed to evaluate the
+// reconstructed signal at any point in time.
+type dspComponent struct {
+	freq      float64
+	amplitude float64
+	phase     float64
+}
+
+// PredictSeries implements SeriesPredictor. It fits the DSP model
+// (resample, estimate the dominant period, FFT, keep the top
+// components) exactly once, then evaluates that single fit at every
+// point in pits. This matters because the fit is the expensive part of
+// a DSP forecast; callers that need several points ahead of the same
+// history, like ShouldDampenScaleDown, should use this instead of
+// calling Predict once per point.
+func (p *DSPPredictor) PredictSeries(history []Observation, currentTime time.Time, pits []float64) ([]float64, error) {
+	if p.SampleInterval <= 0 {
+		return nil, fmt.Errorf("sample interval must be positive, got %s", p.SampleInterval)
+	}
+
+	if len(history) < 4 {
+		return nil, fmt.Errorf("need at least 4 observations to run a DSP forecast, have %d", len(history))
+	}
+
+	samples := resample(history, p.SampleInterval)
+
+	// referenceTime is the real-world instant that index 0 of samples
+	// corresponds to. resample starts its grid at history[0].Time;
+	// truncating below (when the history is longer than one dominant
+	// period) drops leading samples and so advances that instant, which
+	// has to be tracked to correctly phase-align forecasts against
+	// currentTime rather than against the samples array.
+	referenceTime := history[0].Time
+
+	// Pad/truncate to a whole number of the dominant period so the FFT
+	// bins land on whole cycles instead of leaking energy into
+	// neighboring frequencies.
+	period := estimateDominantPeriod(samples)
+	if len(samples) > period {
+		referenceTime = referenceTime.Add(time.Duration(len(samples)-period) * p.SampleInterval)
+	}
+	samples = padOrTruncate(samples, period)
+
+	mean, err := stats.Mean(samples)
+	if err != nil {
+		return nil, err
+	}
+
+	centered := make([]float64, len(samples))
+	for i, v := range samples {
+		centered[i] = v - mean
+	}
+
+	fft := fourier.NewFFT(len(centered))
+	coeffs := fft.Coefficients(nil, centered)
+
+	components := make([]dspComponent, 0, len(coeffs))
+	for i, c := range coeffs {
+		amplitude := cmplx.Abs(c) / float64(len(centered))
+		if amplitude <= p.NoiseThreshold {
+			continue
+		}
+
+		components = append(components, dspComponent{
+			freq:      fft.Freq(i) / p.SampleInterval.Seconds(),
+			amplitude: amplitude,
+			phase:     cmplx.Phase(c),
+		})
+	}
+
+	sort.Slice(components, func(i, j int) bool {
+		return components[i].amplitude > components[j].amplitude
+	})
+
+	if len(components) > p.TopK {
+		components = components[:p.TopK]
+	}
+
+	forecasts := make([]float64, len(pits))
+	for i, pit := range pits {
+		targetTime := currentTime.Add(time.Duration(pit * float64(time.Second)))
+		elapsed := targetTime.Sub(referenceTime).Seconds()
+
+		forecast := mean
+		for _, c := range components {
+			forecast += 2 * c.amplitude * math.Cos(2*math.Pi*c.freq*elapsed+c.phase)
+		}
+		forecasts[i] = forecast
+	}
+
+	return forecasts, nil
+}
+
+// resample linearly interpolates history onto a fixed-interval grid so
+// the FFT operates on evenly spaced samples.
+func resample(history []Observation, interval time.Duration) []float64 {
+	if len(history) == 0 {
+		return nil
+	}
+
+	start := history[0].Time
+	span := history[len(history)-1].Time.Sub(start)
+	if span <= 0 {
+		return []float64{history[0].Value}
+	}
+
+	n := int(span/interval) + 1
+	samples := make([]float64, n)
+
+	idx := 0
+	for i := 0; i < n; i++ {
+		t := start.Add(time.Duration(i) * interval)
+
+		for idx < len(history)-2 && !history[idx+1].Time.After(t) {
+			idx++
+		}
+
+		left := history[idx]
+		right := history[idx]
+		if idx+1 < len(history) {
+			right = history[idx+1]
+		}
+
+		if right.Time.Equal(left.Time) {
+			samples[i] = left.Value
+			continue
+		}
+
+		frac := t.Sub(left.Time).Seconds() / right.Time.Sub(left.Time).Seconds()
+		samples[i] = left.Value + frac*(right.Value-left.Value)
+	}
+
+	return samples
+}
+
+// estimateDominantPeriod finds the lag (in samples) with the highest
+// autocorrelation, which approximates the length of one seasonal cycle.
+func estimateDominantPeriod(samples []float64) int {
+	n := len(samples)
+	if n < 4 {
+		return n
+	}
+
+	mean, _ := stats.Mean(samples)
+	centered := make([]float64, n)
+	for i, v := range samples {
+		centered[i] = v - mean
+	}
+
+	maxLag := n / 2
+	bestLag := 1
+	bestCorrelation := math.Inf(-1)
+
+	for lag := 1; lag <= maxLag; lag++ {
+		sum := 0.0
+		for i := 0; i < n-lag; i++ {
+			sum += centered[i] * centered[i+lag]
+		}
+
+		// Normalize by the number of terms summed. Without this, the
+		// raw sum is biased toward small lags for any realistically
+		// oversampled periodic signal: it has more (n-lag) terms than
+		// a sum at the true period does, which outweighs the true
+		// period's better phase alignment.
+		correlation := sum / float64(n-lag)
+
+		// Every multiple of the true period aligns equally well, so
+		// correlations equal up to floating-point rounding keep the
+		// shorter lag, the fundamental.
+		if lag == 1 || correlation > bestCorrelation+1e-9*math.Abs(bestCorrelation) {
+			bestCorrelation = correlation
+			bestLag = lag
+		}
+	}
+
+	return bestLag
+}
+
+// padOrTruncate resizes samples to exactly n entries: truncating drops
+// the oldest samples, padding repeats the series from the start. Either
+// way the result covers a whole number of the dominant period.
+func padOrTruncate(samples []float64, n int) []float64 {
+	if n <= 0 || len(samples) == 0 || len(samples) == n {
+		return samples
+	}
+
+	if len(samples) > n {
+		return samples[len(samples)-n:]
+	}
+
+	padded := make([]float64, n)
+	for i := range padded {
+		padded[i] = samples[i%len(samples)]
+	}
+
+	return padded
+}
@@ -0,0 +1,259 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podautoscaler
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinearRegressionPredictorEmptyHistory(t *testing.T) {
+	predictor := NewLinearRegressionPredictor()
+
+	_, err := predictor.Predict([]Observation{}, time.Now(), 5.0)
+	assert.NotNil(t, err, "Should error on an empty history.")
+}
+
+func TestLinearRegressionPredictorIncreasingTrend(t *testing.T) {
+	predictor := NewLinearRegressionPredictor()
+
+	now := time.Now()
+	history := []Observation{}
+	for i := 0; i < 10; i++ {
+		history = append(history, Observation{
+			Time:  now.Add(time.Duration(i) * time.Second),
+			Value: float64(i),
+		})
+	}
+
+	predicted, err := predictor.Predict(history, now.Add(9*time.Second), 5.0)
+	assert.Nil(t, err)
+	assert.True(t, predicted > 9.0, "Prediction should extrapolate the upward trend.")
+}
+
+func TestHoltWintersPredictorRequiresTwoSeasons(t *testing.T) {
+	predictor := NewHoltWintersPredictor(0.5, 0.3, 0.3, 4)
+
+	history := []Observation{
+		{Time: time.Now(), Value: 10.0},
+	}
+
+	_, err := predictor.Predict(history, time.Now(), 5.0)
+	assert.NotNil(t, err, "Should error when history is shorter than two seasons.")
+}
+
+func TestHoltWintersPredictorTracksSeasonalPattern(t *testing.T) {
+	predictor := NewHoltWintersPredictor(0.5, 0.3, 0.3, 4)
+
+	season := []float64{10.0, 20.0, 10.0, 0.0}
+	now := time.Now()
+
+	history := []Observation{}
+	for cycle := 0; cycle < 5; cycle++ {
+		for i, v := range season {
+			history = append(history, Observation{
+				Time:  now.Add(time.Duration(cycle*len(season)+i) * time.Minute),
+				Value: v,
+			})
+		}
+	}
+
+	last := history[len(history)-1]
+	// Two steps past the last observation is the cycle's peak (20); the
+	// cycle's mean is 10.
+	predicted, err := predictor.Predict(history, last.Time, 120.0)
+	assert.Nil(t, err)
+	assert.True(t, predicted > 15.0, "Prediction should reflect the seasonal peak rather than collapse to the mean.")
+}
+
+func TestDSPPredictorRejectsNonPositiveSampleInterval(t *testing.T) {
+	predictor := NewDSPPredictor(0, 3, 0.01)
+
+	history := []Observation{
+		{Time: time.Now(), Value: 1.0},
+		{Time: time.Now().Add(time.Minute), Value: 2.0},
+		{Time: time.Now().Add(2 * time.Minute), Value: 3.0},
+		{Time: time.Now().Add(3 * time.Minute), Value: 4.0},
+	}
+
+	_, err := predictor.Predict(history, time.Now(), 60.0)
+	assert.NotNil(t, err, "A zero sample interval should return an error instead of panicking on divide-by-zero.")
+}
+
+func TestDSPPredictorRequiresMinimumHistory(t *testing.T) {
+	predictor := NewDSPPredictor(time.Minute, 3, 0.01)
+
+	_, err := predictor.Predict([]Observation{{Time: time.Now(), Value: 1.0}}, time.Now(), 60.0)
+	assert.NotNil(t, err, "Should error when there are too few observations to run a DSP forecast.")
+}
+
+func TestDSPPredictorReconstructsSinusoid(t *testing.T) {
+	predictor := NewDSPPredictor(time.Minute, 2, 0.5)
+
+	period := 60.0 * 10 // 10 minute period, sampled once per minute.
+	now := time.Now()
+
+	history := []Observation{}
+	for i := 0; i < int(period)/60*3; i++ {
+		seconds := float64(i * 60)
+		value := 50.0 + 20.0*math.Sin(2*math.Pi*seconds/period)
+		history = append(history, Observation{
+			Time:  now.Add(time.Duration(i) * time.Minute),
+			Value: value,
+		})
+	}
+
+	predicted, err := predictor.Predict(history, now, 0)
+	assert.Nil(t, err)
+	assert.True(t, math.Abs(predicted-history[len(history)-1].Value) < 25.0, "Forecast should stay within range of the observed signal.")
+}
+
+func TestHoltWintersPredictorAccountsForElapsedTimeSinceLastObservation(t *testing.T) {
+	predictor := NewHoltWintersPredictor(0.5, 0.3, 0.3, 4)
+
+	season := []float64{10.0, 20.0, 10.0, 0.0}
+	now := time.Now()
+
+	history := []Observation{}
+	for cycle := 0; cycle < 5; cycle++ {
+		for i, v := range season {
+			history = append(history, Observation{
+				Time:  now.Add(time.Duration(cycle*len(season)+i) * time.Minute),
+				Value: v,
+			})
+		}
+	}
+
+	last := history[len(history)-1]
+
+	atLastObservation, err := predictor.Predict(history, last.Time, 60.0)
+	assert.Nil(t, err)
+
+	longAfterLastObservation, err := predictor.Predict(history, last.Time.Add(3*time.Minute), 60.0)
+	assert.Nil(t, err)
+
+	assert.NotEqual(t, atLastObservation, longAfterLastObservation, "A forecast for the same pit should change once currentTime has moved past the last observation, since the target instant is now further ahead.")
+}
+
+func TestDSPPredictorAccountsForElapsedTimeSinceLastObservation(t *testing.T) {
+	predictor := NewDSPPredictor(time.Minute, 2, 0.5)
+
+	period := 60.0 * 10 // 10 minute period, sampled once per minute.
+	now := time.Now()
+
+	history := []Observation{}
+	for i := 0; i < int(period)/60*3; i++ {
+		seconds := float64(i * 60)
+		value := 50.0 + 20.0*math.Sin(2*math.Pi*seconds/period)
+		history = append(history, Observation{
+			Time:  now.Add(time.Duration(i) * time.Minute),
+			Value: value,
+		})
+	}
+
+	last := history[len(history)-1]
+
+	atLastObservation, err := predictor.Predict(history, last.Time, 60.0)
+	assert.Nil(t, err)
+
+	longAfterLastObservation, err := predictor.Predict(history, last.Time.Add(3*time.Minute), 60.0)
+	assert.Nil(t, err)
+
+	assert.NotEqual(t, atLastObservation, longAfterLastObservation, "A forecast for the same pit should change once currentTime has moved past the last observation, since the target instant is now further ahead in the seasonal cycle.")
+}
+
+func TestDSPPredictorSeriesMatchesRepeatedPredict(t *testing.T) {
+	predictor := NewDSPPredictor(time.Minute, 2, 0.5)
+
+	period := 60.0 * 10
+	now := time.Now()
+
+	history := []Observation{}
+	for i := 0; i < int(period)/60*3; i++ {
+		seconds := float64(i * 60)
+		value := 50.0 + 20.0*math.Sin(2*math.Pi*seconds/period)
+		history = append(history, Observation{
+			Time:  now.Add(time.Duration(i) * time.Minute),
+			Value: value,
+		})
+	}
+
+	pits := []float64{0, 60, 120}
+
+	series, err := predictor.PredictSeries(history, now, pits)
+	assert.Nil(t, err)
+	assert.Equal(t, len(pits), len(series))
+
+	for i, pit := range pits {
+		single, err := predictor.Predict(history, now, pit)
+		assert.Nil(t, err)
+		assert.InDelta(t, single, series[i], 1e-9, "PredictSeries should agree with Predict at the same point.")
+	}
+}
+
+func TestPadOrTruncate(t *testing.T) {
+	samples := []float64{1.0, 2.0, 3.0}
+
+	truncated := padOrTruncate(samples, 2)
+	assert.Equal(t, []float64{2.0, 3.0}, truncated, "Truncating should keep the most recent samples.")
+
+	padded := padOrTruncate(samples, 5)
+	assert.Equal(t, []float64{1.0, 2.0, 3.0, 1.0, 2.0}, padded, "Padding should repeat the series from the start.")
+}
+
+func TestEstimateDominantPeriod(t *testing.T) {
+	period := 8
+	samples := make([]float64, period*4)
+	for i := range samples {
+		samples[i] = math.Sin(2 * math.Pi * float64(i) / float64(period))
+	}
+
+	estimated := estimateDominantPeriod(samples)
+	assert.Equal(t, period, estimated, "Should recover the period used to generate the signal.")
+}
+
+func TestEstimateDominantPeriodOversampledSignal(t *testing.T) {
+	// A realistic signal is oversampled relative to its period: e.g. a
+	// one-minute sampling interval over a one-hour (60-sample) daily
+	// cycle, recorded across several days. An unnormalized
+	// autocorrelation sum is biased toward small lags in this regime,
+	// since a small lag simply has more (n-lag) terms to sum over than
+	// the true period does.
+	const period = 60
+	samples := make([]float64, period*20)
+	for i := range samples {
+		samples[i] = 50.0 + 20.0*math.Sin(2*math.Pi*float64(i)/float64(period))
+	}
+
+	estimated := estimateDominantPeriod(samples)
+	assert.Equal(t, period, estimated, "Should recover the true period instead of collapsing to a small lag.")
+
+	reconstructed := padOrTruncate(samples, estimated)
+	minV, maxV := reconstructed[0], reconstructed[0]
+	for _, v := range reconstructed {
+		if v < minV {
+			minV = v
+		}
+		if v > maxV {
+			maxV = v
+		}
+	}
+	assert.True(t, maxV-minV > 10.0, "The fit window recovered from the period estimate should still span the oscillation, not collapse to a near-flat slice.")
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podautoscaler
+
+import (
+	"strconv"
+	"time"
+
+	"k8s.io/kubernetes/pkg/apis/extensions"
+)
+
+// CriticalityTier describes how conservative the scale-down
+// stabilization window should be for an HPA's workload.
+type CriticalityTier string
+
+const (
+	BestEffortCriticality CriticalityTier = "best-effort"
+	BurstableCriticality  CriticalityTier = "burstable"
+	GuaranteedCriticality CriticalityTier = "guaranteed"
+)
+
+const (
+	// StabilizationHorizonAnnotationName overrides, in seconds, the
+	// default look-ahead window used to damp scale-down decisions.
+	StabilizationHorizonAnnotationName = "predictive.stabilization.horizonSeconds"
+
+	// StabilizationSampleCountAnnotationName overrides the number of
+	// future points sampled across the look-ahead window.
+	StabilizationSampleCountAnnotationName = "predictive.stabilization.sampleCount"
+
+	// StabilizationCriticalityAnnotationName selects how conservative
+	// the stabilization window should be: "best-effort", "burstable",
+	// or "guaranteed". Defaults to "best-effort" when unset.
+	StabilizationCriticalityAnnotationName = "predictive.stabilization.criticality"
+
+	defaultStabilizationHorizon = 5 * time.Minute
+	defaultStabilizationSamples = 5
+)
+
+// StabilizationConfig controls how a forecast is used to damp scale-down
+// decisions: how far ahead to look, how many points to sample across
+// that look-ahead, and (via Criticality) how much margin below the
+// target utilization the forecast must clear before a scale-down is
+// allowed.
+type StabilizationConfig struct {
+	Horizon     time.Duration
+	SampleCount int
+	Criticality CriticalityTier
+}
+
+// marginFactor is the fraction of the target utilization a forecast
+// must stay under for a scale-down to proceed. Guaranteed workloads
+// require more headroom than best-effort ones, since flapping is more
+// disruptive for them.
+func (c CriticalityTier) marginFactor() float64 {
+	switch c {
+	case GuaranteedCriticality:
+		return 0.75
+	case BurstableCriticality:
+		return 0.9
+	default:
+		return 1.0
+	}
+}
+
+// horizonMultiplier scales the default look-ahead window so guaranteed
+// workloads look further ahead than best-effort ones unless overridden.
+func (c CriticalityTier) horizonMultiplier() float64 {
+	switch c {
+	case GuaranteedCriticality:
+		return 2.0
+	case BurstableCriticality:
+		return 1.5
+	default:
+		return 1.0
+	}
+}
+
+// StabilizationConfigForHPA reads the stabilization horizon, sample
+// count, and criticality tier from hpa's annotations, falling back to
+// defaults when they are unset or unparsable. Only the default horizon
+// depends on the tier; the default sample count is the same for all.
+func StabilizationConfigForHPA(hpa *extensions.HorizontalPodAutoscaler) StabilizationConfig {
+	criticality := CriticalityTier(hpa.Annotations[StabilizationCriticalityAnnotationName])
+	switch criticality {
+	case GuaranteedCriticality, BurstableCriticality, BestEffortCriticality:
+	default:
+		criticality = BestEffortCriticality
+	}
+
+	horizon := time.Duration(float64(defaultStabilizationHorizon) * criticality.horizonMultiplier())
+	if raw, found := hpa.Annotations[StabilizationHorizonAnnotationName]; found {
+		if seconds, err := strconv.ParseFloat(raw, 64); err == nil && seconds > 0 {
+			horizon = time.Duration(seconds * float64(time.Second))
+		}
+	}
+
+	sampleCount := defaultStabilizationSamples
+	if raw, found := hpa.Annotations[StabilizationSampleCountAnnotationName]; found {
+		if count, err := strconv.Atoi(raw); err == nil && count > 0 {
+			sampleCount = count
+		}
+	}
+
+	return StabilizationConfig{
+		Horizon:     horizon,
+		SampleCount: sampleCount,
+		Criticality: criticality,
+	}
+}
+
+// ShouldDampenScaleDown evaluates predictor at config.SampleCount points
+// spread evenly across config.Horizon and reports whether a scale-down
+// should be refused because one of them forecasts a value that would
+// immediately re-trigger a scale-up under targetUtilization (adjusted by
+// the criticality tier's margin). This is the same "avoidance action"
+// pattern QoS-driven autoscalers use to stop a scale-down and the
+// scale-up it would cause from flapping.
+func ShouldDampenScaleDown(predictor Predictor, history []Observation, currentTime time.Time, targetUtilization float64, config StabilizationConfig) (bool, error) {
+	if config.SampleCount <= 0 {
+		return false, nil
+	}
+
+	threshold := targetUtilization * config.Criticality.marginFactor()
+	stepSeconds := config.Horizon.Seconds() / float64(config.SampleCount)
+
+	pits := make([]float64, config.SampleCount)
+	for i := range pits {
+		pits[i] = stepSeconds * float64(i+1)
+	}
+
+	predicted, err := predictSeries(predictor, history, currentTime, pits)
+	if err != nil {
+		return false, err
+	}
+
+	for _, value := range predicted {
+		if value >= threshold {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// predictSeries evaluates predictor at every point in pits, fitting the
+// model once and reusing it across all of them when predictor
+// implements SeriesPredictor (DSPPredictor's fit in particular is
+// expensive enough that refitting it once per sample on every sync,
+// for every predictive HPA, is worth avoiding). Predictors that only
+// implement Predictor fall back to one call per point.
+func predictSeries(predictor Predictor, history []Observation, currentTime time.Time, pits []float64) ([]float64, error) {
+	if series, ok := predictor.(SeriesPredictor); ok {
+		return series.PredictSeries(history, currentTime, pits)
+	}
+
+	forecasts := make([]float64, len(pits))
+	for i, pit := range pits {
+		predicted, err := predictor.Predict(history, currentTime, pit)
+		if err != nil {
+			return nil, err
+		}
+		forecasts[i] = predicted
+	}
+
+	return forecasts, nil
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podautoscaler
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"k8s.io/kubernetes/pkg/apis/extensions"
+)
+
+func TestStabilizationConfigForHPADefaults(t *testing.T) {
+	hpa := &extensions.HorizontalPodAutoscaler{}
+
+	config := StabilizationConfigForHPA(hpa)
+
+	assert.Equal(t, BestEffortCriticality, config.Criticality)
+	assert.Equal(t, defaultStabilizationHorizon, config.Horizon)
+	assert.Equal(t, defaultStabilizationSamples, config.SampleCount)
+}
+
+func TestStabilizationConfigForHPAGuaranteedLooksFurtherAhead(t *testing.T) {
+	hpa := &extensions.HorizontalPodAutoscaler{}
+	writeToHPAAnnotations(hpa, StabilizationCriticalityAnnotationName, string(GuaranteedCriticality))
+
+	config := StabilizationConfigForHPA(hpa)
+
+	assert.Equal(t, GuaranteedCriticality, config.Criticality)
+	assert.True(t, config.Horizon > defaultStabilizationHorizon, "Guaranteed workloads should default to a longer look-ahead.")
+}
+
+func TestStabilizationConfigForHPAHonorsOverrides(t *testing.T) {
+	hpa := &extensions.HorizontalPodAutoscaler{}
+	writeToHPAAnnotations(hpa, StabilizationHorizonAnnotationName, "600")
+	writeToHPAAnnotations(hpa, StabilizationSampleCountAnnotationName, "10")
+
+	config := StabilizationConfigForHPA(hpa)
+
+	assert.Equal(t, 10*time.Minute, config.Horizon)
+	assert.Equal(t, 10, config.SampleCount)
+}
+
+// TestShouldDampenScaleDownSinusoidalLoad shows that a workload whose
+// utilization oscillates sinusoidally no longer flaps replicas: at a
+// trough, where the instantaneous utilization alone would trigger a
+// scale-down, the stabilization window looks far enough ahead to see
+// the next peak and refuses the scale-down.
+func TestShouldDampenScaleDownSinusoidalLoad(t *testing.T) {
+	const (
+		period        = 10 * time.Minute
+		sampleEvery   = time.Minute
+		meanUtil      = 60.0
+		amplitude     = 30.0
+		targetUtil    = 60.0
+		seasonSamples = int(period / sampleEvery)
+	)
+
+	predictor := NewHoltWintersPredictor(0.6, 0.2, 0.6, seasonSamples)
+
+	// troughIndex is three quarters of the way into the final, partial
+	// cycle, rounded down (index 7, phase 1.4*pi, just short of the
+	// trough at 1.5*pi): instantaneous utilization there is well under targetUtil,
+	// where a naive controller would scale down immediately.
+	troughIndex := seasonSamples * 3 / 4
+
+	now := time.Now()
+	history := []Observation{}
+	for cycle := 0; cycle < 3; cycle++ {
+		for i := 0; i < seasonSamples; i++ {
+			offset := time.Duration(cycle*seasonSamples+i) * sampleEvery
+			phase := 2 * math.Pi * float64(i) / float64(seasonSamples)
+			history = append(history, Observation{
+				Time:  now.Add(offset),
+				Value: meanUtil + amplitude*math.Sin(phase),
+			})
+		}
+	}
+	for i := 0; i <= troughIndex; i++ {
+		offset := time.Duration(3*seasonSamples+i) * sampleEvery
+		phase := 2 * math.Pi * float64(i) / float64(seasonSamples)
+		history = append(history, Observation{
+			Time:  now.Add(offset),
+			Value: meanUtil + amplitude*math.Sin(phase),
+		})
+	}
+
+	// currentTime is the timestamp of the last recorded observation, so
+	// history holds nothing predictor shouldn't yet know about.
+	currentTime := history[len(history)-1].Time
+
+	config := StabilizationConfig{
+		Horizon:     period,
+		SampleCount: seasonSamples,
+		Criticality: BestEffortCriticality,
+	}
+
+	dampen, err := ShouldDampenScaleDown(predictor, history, currentTime, targetUtil, config)
+	assert.Nil(t, err)
+	assert.True(t, dampen, "Should refuse the scale-down because the forecast shows a peak within the horizon.")
+}
+
+// fitCountingSeriesPredictor implements SeriesPredictor and records how
+// many times its (expensive) fit would run, so tests can assert that
+// ShouldDampenScaleDown amortizes it across samples instead of calling
+// Predict once per sample.
+type fitCountingSeriesPredictor struct {
+	fitCount int
+}
+
+func (p *fitCountingSeriesPredictor) Predict(history []Observation, currentTime time.Time, pit float64) (float64, error) {
+	forecasts, err := p.PredictSeries(history, currentTime, []float64{pit})
+	if err != nil {
+		return 0, err
+	}
+	return forecasts[0], nil
+}
+
+func (p *fitCountingSeriesPredictor) PredictSeries(history []Observation, currentTime time.Time, pits []float64) ([]float64, error) {
+	p.fitCount++
+
+	forecasts := make([]float64, len(pits))
+	for i := range pits {
+		forecasts[i] = 0.0
+	}
+	return forecasts, nil
+}
+
+func TestShouldDampenScaleDownFitsSeriesPredictorOnce(t *testing.T) {
+	predictor := &fitCountingSeriesPredictor{}
+
+	config := StabilizationConfig{
+		Horizon:     5 * time.Minute,
+		SampleCount: 5,
+		Criticality: BestEffortCriticality,
+	}
+
+	_, err := ShouldDampenScaleDown(predictor, []Observation{}, time.Now(), 50.0, config)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, predictor.fitCount, "A SeriesPredictor's fit should run once per ShouldDampenScaleDown call, not once per sample.")
+}
+
+func TestShouldDampenScaleDownStableLowLoad(t *testing.T) {
+	predictor := NewLinearRegressionPredictor()
+
+	now := time.Now()
+	history := []Observation{
+		{Time: now, Value: 10.0},
+		{Time: now.Add(time.Minute), Value: 10.0},
+		{Time: now.Add(2 * time.Minute), Value: 10.0},
+	}
+
+	config := StabilizationConfig{
+		Horizon:     5 * time.Minute,
+		SampleCount: 5,
+		Criticality: BestEffortCriticality,
+	}
+
+	dampen, err := ShouldDampenScaleDown(predictor, history, now.Add(2*time.Minute), 60.0, config)
+	assert.Nil(t, err)
+	assert.False(t, dampen, "A steady low-utilization forecast should allow the scale-down to proceed.")
+}
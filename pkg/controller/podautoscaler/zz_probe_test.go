@@ -0,0 +1,34 @@
+package podautoscaler
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestProbeEstimateDominantPeriod(t *testing.T) {
+	n := 1440
+	samples := make([]float64, n)
+	for i := 0; i < n; i++ {
+		// period of 60 samples (1 hour at 1-min sampling)
+		samples[i] = 50 + 20*math.Sin(2*math.Pi*float64(i)/60.0)
+	}
+	period := estimateDominantPeriod(samples)
+	fmt.Println("estimated period:", period)
+}
+
+func TestProbeDSPPredictSeries(t *testing.T) {
+	now := time.Now()
+	history := make([]Observation, 0)
+	for i := 0; i < 1440; i++ {
+		v := 50 + 20*math.Sin(2*math.Pi*float64(i)/60.0)
+		history = append(history, Observation{Time: now.Add(time.Duration(i) * time.Minute), Value: v})
+	}
+	p := NewDSPPredictor(time.Minute, 5, 0.5)
+	forecasts, err := p.PredictSeries(history, now.Add(1440*time.Minute), []float64{0, 900, 1800, 2700, 3600})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println("forecasts:", forecasts)
+}